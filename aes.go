@@ -0,0 +1,61 @@
+package rfm69
+
+import "log"
+
+// RegAesKey1 is already declared in this package's register map; this file
+// just writes the 16 consecutive key registers (0x3E-0x4D) starting there.
+
+const (
+	aesKeyLen = 16
+
+	// PacketConfig2AesOn is the AES-on bit in RegPacketConfig2: once set, the
+	// FIFO payload (everything after the RadioHead LENGTH byte) is
+	// transparently encrypted on transmit and decrypted on receive.
+	PacketConfig2AesOn = 0x01
+
+	// With AES enabled, the LENGTH byte still counts against the FIFO but
+	// isn't itself encrypted, so the usable payload shrinks relative to
+	// maxPacketSize. MaxPacketSize reports this as a limit on len(data); it
+	// doesn't itself account for the 4-byte RadioHead header SendRadioHead
+	// adds on top, so this is 4 bytes under the radio's real 64-byte ceiling
+	// under AES.
+	maxPacketSizeAES = 60
+)
+
+// SetAESKey arms the RFM69's AES-128 payload encryption with key, or disarms
+// it if key is nil or empty. It is safe to call while in StandbyMode, and
+// should be called on both ends of a link with the same key.
+func (r *Radio) SetAESKey(key []byte) {
+	if r.Error() != nil {
+		return
+	}
+	if len(key) != 0 && len(key) != aesKeyLen {
+		log.Panicf("AES key must be %d bytes, got %d", aesKeyLen, len(key))
+	}
+	r.setMode(StandbyMode)
+	buf := make([]byte, aesKeyLen)
+	copy(buf, key)
+	r.hw.WriteBurst(RegAesKey1, buf)
+	packetConfig2 := r.hw.ReadRegister(RegPacketConfig2)
+	if len(key) == 0 {
+		packetConfig2 &^= PacketConfig2AesOn
+	} else {
+		packetConfig2 |= PacketConfig2AesOn
+	}
+	r.hw.WriteRegister(RegPacketConfig2, packetConfig2)
+}
+
+// aesEnabled reports whether the radio currently has AES encryption armed.
+func (r *Radio) aesEnabled() bool {
+	return r.hw.ReadRegister(RegPacketConfig2)&PacketConfig2AesOn != 0
+}
+
+// MaxPacketSize returns the largest RadioHead payload SendRadioHead will
+// currently accept: maxPacketSize, or the smaller maxPacketSizeAES once
+// SetAESKey has armed encryption.
+func (r *Radio) MaxPacketSize() int {
+	if r.aesEnabled() {
+		return maxPacketSizeAES
+	}
+	return maxPacketSize
+}
@@ -0,0 +1,133 @@
+package fec
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DanCrank/rfm69"
+)
+
+// frameHeaderLen is the small per-chunk sequence header [index, total,
+// chunkLen] prepended before RS coding, so a large message can be split
+// across multiple RFM69 frames and reassembled on the far end.
+const frameHeaderLen = 3
+
+// Codec wraps a Radio's Send/Receive with RS(n,k) forward error correction,
+// chunking messages that don't fit in a single RS codeword across multiple
+// RadioHead frames.
+type Codec struct {
+	radio *rfm69.Radio
+	rs    *RS
+}
+
+// NewCodec wraps radio with an RS(n,k) codec. n must not exceed
+// radio.MaxPacketSize() (a smaller limit once radio has an AES key set; the
+// RadioHead header the radio adds is already excluded from it), otherwise
+// the radio would panic the first time a codeword-sized frame was sent.
+func NewCodec(radio *rfm69.Radio, n, k int) (*Codec, error) {
+	rs, err := NewRS(n, k)
+	if err != nil {
+		return nil, err
+	}
+	if max := radio.MaxPacketSize(); n > max {
+		return nil, fmt.Errorf("fec: RS codeword of %d bytes exceeds the radio's %d-byte max packet size", n, max)
+	}
+	return &Codec{radio: radio, rs: rs}, nil
+}
+
+// Send RS-encodes data, chunking it across multiple RadioHead frames if it
+// doesn't fit in one codeword's payload.
+func (c *Codec) Send(data []byte, to, from, id, flags byte) error {
+	payloadPerChunk := c.rs.K() - frameHeaderLen
+	if payloadPerChunk <= 0 {
+		return fmt.Errorf("fec: RS(%d,%d) leaves no room for message bytes", c.rs.N(), c.rs.K())
+	}
+	total := (len(data) + payloadPerChunk - 1) / payloadPerChunk
+	if total == 0 {
+		total = 1
+	}
+	if total > 255 {
+		return fmt.Errorf("fec: %d-byte message needs %d chunks, max 255", len(data), total)
+	}
+	for i := 0; i < total; i++ {
+		start := i * payloadPerChunk
+		end := start + payloadPerChunk
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := make([]byte, 0, frameHeaderLen+payloadPerChunk)
+		chunk = append(chunk, byte(i), byte(total), byte(end-start))
+		chunk = append(chunk, data[start:end]...)
+		encoded, err := c.rs.Encode(chunk)
+		if err != nil {
+			return err
+		}
+		c.radio.SendRadioHead(encoded, to, from, id, flags)
+		if err := c.radio.Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive waits up to timeout for every chunk of one RS-coded message, RS-
+// corrects each frame, reassembles them in order, and returns the message
+// alongside the total bytes corrected across all chunks (a rough link-margin
+// indicator). Frames that fail RS correction are dropped and waited past.
+func (c *Codec) Receive(timeout time.Duration) ([]byte, int, error) {
+	var chunks [][]byte
+	total := -1
+	correctedTotal := 0
+	deadline := time.Now().Add(timeout)
+	for total == -1 || chunksMissing(chunks) {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			got := 0
+			if chunks != nil {
+				got = len(chunks) - countMissing(chunks)
+			}
+			return nil, 0, fmt.Errorf("fec: timed out with %d/%d chunks", got, total)
+		}
+		p, _, err := c.radio.Receive(remaining)
+		if err != nil || len(p) < 4 {
+			continue
+		}
+		encoded := p[4:]
+		if len(encoded) != c.rs.N() {
+			continue // not one of ours
+		}
+		decoded, corrected, err := c.rs.Decode(encoded)
+		if err != nil {
+			continue // drop the uncorrectable frame and keep waiting
+		}
+		idx, chunkTotal, chunkLen := int(decoded[0]), int(decoded[1]), int(decoded[2])
+		if total == -1 {
+			total = chunkTotal
+			chunks = make([][]byte, total)
+		}
+		if chunkTotal != total || idx >= total || chunks[idx] != nil {
+			continue
+		}
+		chunks[idx] = decoded[frameHeaderLen : frameHeaderLen+chunkLen]
+		correctedTotal += corrected
+	}
+	var out []byte
+	for _, ch := range chunks {
+		out = append(out, ch...)
+	}
+	return out, correctedTotal, nil
+}
+
+func chunksMissing(chunks [][]byte) bool {
+	return countMissing(chunks) > 0
+}
+
+func countMissing(chunks [][]byte) int {
+	n := 0
+	for _, c := range chunks {
+		if c == nil {
+			n++
+		}
+	}
+	return n
+}
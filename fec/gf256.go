@@ -0,0 +1,64 @@
+// Package fec is an opt-in Reed-Solomon forward error correction layer for
+// rfm69, for links too noisy to rely on the radio's own CRC alone.
+package fec
+
+// GF(256) arithmetic using the standard 0x11D primitive polynomial (the same
+// field CDs, QR codes and most Reed-Solomon codecs are built on), with alpha
+// = 2 as the generator.
+const (
+	gfFieldSize = 256
+	gfPrimPoly  = 0x11D
+)
+
+var gfExp [2 * gfFieldSize]byte
+var gfLog [gfFieldSize]byte
+
+func init() {
+	x := 1
+	for i := 0; i < gfFieldSize-1; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&gfFieldSize != 0 {
+			x ^= gfPrimPoly
+		}
+	}
+	for i := gfFieldSize - 1; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-(gfFieldSize-1)]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	if b == 0 {
+		panic("fec: division by zero in GF(256)")
+	}
+	return gfExp[(int(gfLog[a])-int(gfLog[b])+255)%255]
+}
+
+func gfPow(a byte, n int) byte {
+	if a == 0 {
+		if n == 0 {
+			return 1
+		}
+		return 0
+	}
+	e := (int(gfLog[a]) * n) % 255
+	if e < 0 {
+		e += 255
+	}
+	return gfExp[e]
+}
+
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
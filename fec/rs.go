@@ -0,0 +1,248 @@
+package fec
+
+import "fmt"
+
+// RS is a systematic Reed-Solomon code over GF(256): k data bytes followed
+// by n-k parity bytes, correcting up to (n-k)/2 byte errors per codeword.
+// There is no default (n,k): RS(255,223) is the classic CCSDS choice, while
+// Codec needs smaller pairs so a codeword fits inside a single RFM69 frame.
+type RS struct {
+	n, k int
+	gen  []byte // generator polynomial, descending powers, leading coefficient 1
+}
+
+// NewRS returns an RS(n,k) codec.
+func NewRS(n, k int) (*RS, error) {
+	if n <= k || k <= 0 || n > 255 {
+		return nil, fmt.Errorf("fec: invalid RS(%d,%d)", n, k)
+	}
+	return &RS{n: n, k: k, gen: genPoly(n - k)}, nil
+}
+
+// N returns the codeword size in bytes (data + parity).
+func (r *RS) N() int { return r.n }
+
+// K returns the data (payload) size in bytes.
+func (r *RS) K() int { return r.k }
+
+// genPoly builds prod_{i=0}^{parity-1} (x - alpha^i), descending powers.
+func genPoly(parity int) []byte {
+	g := []byte{1}
+	for i := 0; i < parity; i++ {
+		g = polyMul(g, []byte{1, gfPow(2, i)})
+	}
+	return g
+}
+
+// polyMul multiplies two descending-power polynomials over GF(256).
+func polyMul(a, b []byte) []byte {
+	res := make([]byte, len(a)+len(b)-1)
+	for i, ca := range a {
+		if ca == 0 {
+			continue
+		}
+		for j, cb := range b {
+			res[i+j] ^= gfMul(ca, cb)
+		}
+	}
+	return res
+}
+
+// polyMod computes msg mod gen (gen monic), the standard LFSR-style
+// systematic-encoder remainder: the trailing len(gen)-1 bytes of the result
+// are the parity.
+func polyMod(msg, gen []byte) []byte {
+	remainder := append([]byte(nil), msg...)
+	for i := 0; i <= len(msg)-len(gen); i++ {
+		coef := remainder[i]
+		if coef == 0 {
+			continue
+		}
+		for j := 1; j < len(gen); j++ {
+			if gen[j] != 0 {
+				remainder[i+j] ^= gfMul(gen[j], coef)
+			}
+		}
+	}
+	return remainder[len(msg)-len(gen)+1:]
+}
+
+// Encode returns the n-byte systematic codeword for data (which must fit in
+// k bytes; short messages are zero-padded).
+func (r *RS) Encode(data []byte) ([]byte, error) {
+	if len(data) > r.k {
+		return nil, fmt.Errorf("fec: %d-byte message exceeds RS(%d,%d) capacity", len(data), r.n, r.k)
+	}
+	parity := r.n - r.k
+	padded := make([]byte, r.k+parity)
+	copy(padded, data)
+	remainder := polyMod(padded, r.gen)
+	out := make([]byte, r.n)
+	copy(out, padded[:r.k])
+	copy(out[r.k:], remainder[len(remainder)-parity:])
+	return out, nil
+}
+
+// Decode corrects up to (n-k)/2 byte errors in an n-byte codeword and
+// returns the k-byte systematic payload plus the number of bytes corrected.
+// It returns an error if the codeword is uncorrectable.
+func (r *RS) Decode(codeword []byte) ([]byte, int, error) {
+	if len(codeword) != r.n {
+		return nil, 0, fmt.Errorf("fec: codeword is %d bytes, want %d", len(codeword), r.n)
+	}
+	parity := r.n - r.k
+	syn := syndromes(codeword, parity)
+	if allZero(syn) {
+		return append([]byte(nil), codeword[:r.k]...), 0, nil
+	}
+	errLoc := berlekampMassey(syn)
+	errPos := chienSearch(errLoc, r.n)
+	if len(errPos) == 0 || len(errPos) != len(errLoc)-1 || len(errPos) > parity/2 {
+		return nil, 0, fmt.Errorf("fec: uncorrectable codeword (too many errors for RS(%d,%d))", r.n, r.k)
+	}
+	corrected := append([]byte(nil), codeword...)
+	if err := forney(corrected, syn, errLoc, errPos); err != nil {
+		return nil, 0, err
+	}
+	if !allZero(syndromes(corrected, parity)) {
+		return nil, 0, fmt.Errorf("fec: uncorrectable codeword (correction failed verification for RS(%d,%d))", r.n, r.k)
+	}
+	return corrected[:r.k], len(errPos), nil
+}
+
+// polyEval evaluates a descending-power polynomial at x (Horner's method).
+func polyEval(p []byte, x byte) byte {
+	y := p[0]
+	for _, c := range p[1:] {
+		y = gfMul(y, x) ^ c
+	}
+	return y
+}
+
+func syndromes(codeword []byte, parity int) []byte {
+	syn := make([]byte, parity)
+	for i := 0; i < parity; i++ {
+		syn[i] = polyEval(codeword, gfPow(2, i))
+	}
+	return syn
+}
+
+func allZero(p []byte) bool {
+	for _, b := range p {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// berlekampMassey finds the error-locator polynomial (ascending powers,
+// errLoc[0]==1) of minimal degree satisfying the syndromes.
+func berlekampMassey(syn []byte) []byte {
+	c := make([]byte, len(syn)+1)
+	b := make([]byte, len(syn)+1)
+	c[0], b[0] = 1, 1
+	l, m := 0, 1
+	bb := byte(1)
+	for n := 0; n < len(syn); n++ {
+		delta := syn[n]
+		for i := 1; i <= l; i++ {
+			delta ^= gfMul(c[i], syn[n-i])
+		}
+		if delta == 0 {
+			m++
+			continue
+		}
+		t := append([]byte(nil), c...)
+		coef := gfDiv(delta, bb)
+		for i := 0; i+m < len(c); i++ {
+			c[i+m] ^= gfMul(coef, b[i])
+		}
+		if 2*l <= n {
+			l = n + 1 - l
+			b = t
+			bb = delta
+			m = 1
+		} else {
+			m++
+		}
+	}
+	return c[:l+1]
+}
+
+// chienSearch finds the roots of errLoc by brute-force evaluation at
+// alpha^-j for each of the n codeword positions j, returning the
+// corresponding codeword byte positions
+// (codeword[0] holds the x^(n-1) coefficient).
+func chienSearch(errLoc []byte, n int) []int {
+	var pos []int
+	for j := 0; j < n; j++ {
+		x := gfPow(2, 255-j) // alpha^-j
+		if polyEvalAsc(errLoc, x) == 0 {
+			pos = append(pos, n-1-j)
+		}
+	}
+	return pos
+}
+
+// polyEvalAsc evaluates an ascending-power polynomial at x.
+func polyEvalAsc(p []byte, x byte) byte {
+	var y byte
+	xp := byte(1)
+	for _, c := range p {
+		y ^= gfMul(c, xp)
+		xp = gfMul(xp, x)
+	}
+	return y
+}
+
+// forney computes each error's magnitude via the error evaluator polynomial
+// Omega(x) = S(x)*errLoc(x) mod x^len(syn), and XORs it into corrected.
+func forney(corrected, syn, errLoc []byte, errPos []int) error {
+	omega := polyMulTrunc(syn, errLoc, len(syn))
+	lambdaPrime := polyFormalDerivative(errLoc)
+	n := len(corrected)
+	for _, pos := range errPos {
+		j := n - 1 - pos
+		x0 := gfPow(2, 255-j) // = X_k^-1, the root chienSearch found
+		xk := gfInv(x0)
+		denom := polyEvalAsc(lambdaPrime, x0)
+		if denom == 0 {
+			return fmt.Errorf("fec: uncorrectable: zero error-locator derivative at position %d", pos)
+		}
+		e := gfMul(xk, gfDiv(polyEvalAsc(omega, x0), denom))
+		corrected[pos] ^= e
+	}
+	return nil
+}
+
+func polyMulTrunc(a, b []byte, limit int) []byte {
+	res := make([]byte, limit)
+	for i, ca := range a {
+		if ca == 0 || i >= limit {
+			continue
+		}
+		for j, cb := range b {
+			if i+j >= limit {
+				break
+			}
+			res[i+j] ^= gfMul(ca, cb)
+		}
+	}
+	return res
+}
+
+// polyFormalDerivative differentiates an ascending-power GF(2^m) polynomial:
+// odd-power terms survive (coefficient i mod 2), even-power terms vanish.
+func polyFormalDerivative(p []byte) []byte {
+	if len(p) <= 1 {
+		return []byte{0}
+	}
+	d := make([]byte, len(p)-1)
+	for i := 1; i < len(p); i++ {
+		if i%2 == 1 {
+			d[i-1] = p[i]
+		}
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package rfm69
+
+import "time"
+
+// RegListen1/RegListen2/RegListen3 are already declared in this package's
+// register map; this file just writes them.
+
+const (
+	// OpModeListenOn/OpModeListenAbort live in RegOpMode alongside the usual
+	// Sleep/Standby/FS/Tx/Rx mode bits.
+	OpModeListenOn    = 0x40
+	OpModeListenAbort = 0x20
+
+	// listenResol4ms and listenResol262ms select the idle-timer
+	// resolution in RegListen1 bits 7:6; the Rx ones select bits 5:4. Either
+	// field multiplies its resolution by the matching RegListen2/3
+	// coefficient to get the actual idle/rx duration.
+	listenResol4ms     = 0x02 << 6
+	listenResol262ms   = 0x03 << 6
+	listenResolRx4ms   = 0x02 << 4
+	listenResolRx262ms = 0x03 << 4
+
+	listenResolUnit    = 4100 * time.Microsecond
+	listenResolRxUnit  = 4100 * time.Microsecond
+	listenResolBigUnit = 262000 * time.Microsecond
+)
+
+// EnterListenMode puts the radio into its low-duty-cycle Listen Mode: it
+// wakes every idle period, sniffs for a preamble and sync match for up to rx,
+// and only stays awake in receive if that sync match hits. Call Receive
+// afterwards to wait for a packet to actually arrive; the radio stays in
+// Listen Mode across repeated Receive calls until ExitListenMode is called.
+func (r *Radio) EnterListenMode(idle, rx time.Duration) {
+	if r.Error() != nil {
+		return
+	}
+	idleResol, idleCoef := listenCoef(idle, listenResolUnit)
+	rxResol, rxCoef := listenCoef(rx, listenResolRxUnit)
+	rxResolBits := listenResolRx4ms
+	if rxResol == listenResol262ms {
+		rxResolBits = listenResolRx262ms
+	}
+	r.setMode(StandbyMode)
+	r.hw.WriteRegister(RegListen1, idleResol|byte(rxResolBits)|0x02 /* ListenCriteria: sync address match */)
+	r.hw.WriteRegister(RegListen2, idleCoef)
+	r.hw.WriteRegister(RegListen3, rxCoef)
+	opMode := r.hw.ReadRegister(RegOpMode)
+	r.hw.WriteRegister(RegOpMode, opMode|OpModeListenOn)
+}
+
+// ExitListenMode takes the radio back out of Listen Mode, as recommended by
+// the datasheet: set ListenAbort alongside Mode=Standby, then drop ListenOn.
+func (r *Radio) ExitListenMode() {
+	if r.Error() != nil {
+		return
+	}
+	opMode := r.hw.ReadRegister(RegOpMode)
+	r.hw.WriteRegister(RegOpMode, (opMode&^byte(ModeMask))|OpModeListenAbort|StandbyMode)
+	r.hw.WriteRegister(RegOpMode, r.hw.ReadRegister(RegOpMode)&^byte(OpModeListenOn|OpModeListenAbort))
+}
+
+// listenCoef uses smallUnit when d is 1 to 255 whole multiples of it, and
+// otherwise the 262ms unit with its coefficient clamped to 1..255, and returns
+// the resolution bits for RegListen1 alongside that coefficient.
+func listenCoef(d, smallUnit time.Duration) (resolBits byte, coef byte) {
+	if n := d / smallUnit; n > 0 && n <= 255 {
+		return listenResol4ms, byte(n)
+	}
+	n := d / listenResolBigUnit
+	if n < 1 {
+		n = 1
+	}
+	if n > 255 {
+		n = 255
+	}
+	return listenResol262ms, byte(n)
+}
+
+// WakeListener transmits data to a node sleeping in Listen Mode, stretching
+// the outgoing preamble to preambleDuration first so it's long enough to
+// guarantee overlap with the listener's next RX window, then restores the
+// radio's normal preamble length.
+func (r *Radio) WakeListener(data []byte, to, from, id, flags byte, preambleDuration time.Duration) {
+	if r.Error() != nil {
+		return
+	}
+	preambleBytes := uint16(preambleDuration / byteDuration)
+	if preambleBytes < 1 {
+		preambleBytes = 1
+	}
+	origMsb := r.hw.ReadRegister(RegPreambleMsb)
+	origLsb := r.hw.ReadRegister(RegPreambleLsb)
+	r.hw.WriteRegister(RegPreambleMsb, byte(preambleBytes>>8))
+	r.hw.WriteRegister(RegPreambleLsb, byte(preambleBytes))
+	r.SendRadioHead(data, to, from, id, flags)
+	r.hw.WriteRegister(RegPreambleMsb, origMsb)
+	r.hw.WriteRegister(RegPreambleLsb, origLsb)
+}
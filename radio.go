@@ -1,10 +1,23 @@
 package rfm69
 
 import (
+	"errors"
 	"log"
 	"time"
 )
 
+// Errors Receive and ReceiveInfo can return to let callers distinguish "no
+// packet" (nil, nil) from a packet that arrived but was corrupted.
+var (
+	// ErrBadLength means the FIFO's LENGTH byte was implausible (a bit-flip
+	// at the noise floor is a common cause); the FIFO has been flushed.
+	ErrBadLength = errors.New("rfm69: corrupt LENGTH byte")
+	// ErrCrcFail means a full packet arrived but failed the radio's CRC check.
+	ErrCrcFail = errors.New("rfm69: CRC check failed")
+	// ErrFifoOverrun means the FIFO overran while a packet was being drained.
+	ErrFifoOverrun = errors.New("rfm69: FIFO overrun")
+)
+
 const (
 	debug         = false
 	maxPacketSize = 110
@@ -16,6 +29,14 @@ const (
 
 	// Approximate time for one byte to be transmitted, based on the data rate.
 	byteDuration = time.Millisecond
+
+	// DioMapping1Dio0PacketSent is RegDioMapping1's reset value: DIO0 mapped
+	// to PacketSent while in packet-mode Tx.
+	DioMapping1Dio0PacketSent = 0x00
+
+	// txTimeoutMargin is added on top of the computed transmit time before
+	// giving up on AwaitInterrupt and falling back to polling.
+	txTimeoutMargin = 50 * time.Millisecond
 )
 
 func init() {
@@ -32,7 +53,7 @@ func (r *Radio) SendRadioHead(data []byte, to byte, from byte, id byte, flags by
 	if r.Error() != nil {
 		return
 	}
-	if len(data) > maxPacketSize {
+	if len(data) > r.MaxPacketSize() {
 		log.Panicf("attempting to send %d-byte packet", len(data))
 	}
 	if debug {
@@ -56,10 +77,12 @@ func (r *Radio) Send(data []byte) {
 	r.SendRadioHead(data, 0xFF, 0xFF, 0x00, 0x00)
 }
 
+// transmit writes data to the radio and keys up the transmitter. Packets
+// longer than fifoSize are streamed: the first fifoSize bytes prime the FIFO
+// before TransmitterMode is entered, and whenever FifoLevel later reports the
+// FIFO has drained below fifoThreshold, another fifoSize-fifoThreshold bytes
+// are topped up, until the whole payload has been handed to the radio.
 func (r *Radio) transmit(data []byte) {
-	if len(data) > fifoSize {
-		log.Panicf("Send packet too big, %d bytes!", len(data))
-	}
 	r.clearFIFO()
 	r.hw.WriteRegister(RegAutoModes, 0)
 	if debug {
@@ -68,16 +91,44 @@ func (r *Radio) transmit(data []byte) {
 			log.Printf("0x%02X", b)
 		}
 	}
-	r.hw.WriteBurst(RegFifo, data)
+	burst := data
+	if len(burst) > fifoSize {
+		burst = data[:fifoSize]
+	}
+	r.hw.WriteBurst(RegFifo, burst)
+	sent := len(burst)
+	// DIO0 defaults to PacketSent in packet-mode Tx (RegDioMapping1 bits 7:6
+	// = 00), so AwaitInterrupt below wakes as soon as the packet is fully
+	// sent rather than us polling RegIrqFlags2 every byteDuration.
+	r.hw.WriteRegister(RegDioMapping1, DioMapping1Dio0PacketSent)
 	r.setMode(TransmitterMode)
+	for sent < len(data) && r.Error() == nil {
+		if r.fifoThresholdExceeded() {
+			continue
+		}
+		chunk := fifoSize - fifoThreshold
+		if remaining := len(data) - sent; chunk > remaining {
+			chunk = remaining
+		}
+		r.hw.WriteBurst(RegFifo, data[sent:sent+chunk])
+		sent += chunk
+	}
+	// AwaitInterrupt blocks until DIO0 fires or timeout elapses; it's a no-op
+	// wait (and returns immediately) on hardware with no GPIO wired to DIO0,
+	// so the poll below is still needed as a fallback either way - it'll see
+	// PacketSent already set and exit on its first iteration when the
+	// interrupt did fire.
+	timeout := time.Duration(len(data))*byteDuration + txTimeoutMargin
+	r.hw.AwaitInterrupt(timeout)
 	for r.Error() == nil {
-		if (r.hw.ReadRegister(RegIrqFlags2) & 0x08) != 0 {
+		if (r.hw.ReadRegister(RegIrqFlags2) & PacketSent) != 0 {
 			break
 		}
-		//log.Print("Transmit not done yet")
 		time.Sleep(byteDuration)
 	}
-	log.Print("Transmit done!")
+	if debug {
+		log.Print("Transmit done!")
+	}
 }
 
 func (r *Radio) fifoEmpty() bool {
@@ -96,20 +147,52 @@ func (r *Radio) clearFIFO() {
 	r.hw.WriteRegister(RegIrqFlags2, FifoOverrun)
 }
 
+// CrcErrors returns the number of received packets so far that failed the
+// radio's CRC check. Receive discards such packets; ReceiveInfo returns them
+// with CrcOK false.
+func (r *Radio) CrcErrors() int {
+	return r.crcErrors
+}
+
 // Receive listens with the given timeout for an incoming packet.
 // It returns the packet and the associated RSSI.
+// If the radio is already in Listen Mode (see EnterListenMode), it is left
+// there and Receive simply waits on the sync-match/payload-ready interrupt
+// instead of forcing ReceiverMode.
 // The RadioHead protocol uses the first five bytes of the payload as a header:
 // LENGTH, TO, FROM, ID, FLAGS. The LENGTH value is inclusive of the last four header
 // bytes but exclusive of the LENGTH byte (so the actual LENGTH is payload + 4).
 // The packet is returned from this function with the four header bytes at the
 // head, so the caller can read and/or discard them.
-func (r *Radio) Receive(timeout time.Duration) ([]byte, int) {
-	if r.Error() != nil {
-		return nil, 0
+//
+// See ReceiveInfo for a variant that also returns RSSI, AFC/FEI, CRC status
+// and the parsed RadioHead header.
+//
+// A corrupted LENGTH byte or a failed CRC are reported as ErrBadLength or
+// ErrCrcFail respectively, rather than being indistinguishable from a plain
+// receive timeout (nil packet, nil error).
+func (r *Radio) Receive(timeout time.Duration) ([]byte, int, error) {
+	p, rssi, meta, err := r.receiveRaw(timeout)
+	if err != nil {
+		return nil, rssi, err
+	}
+	if p != nil && !meta.crcOK {
+		return nil, rssi, ErrCrcFail
+	}
+	return p, rssi, nil
+}
+
+// receiveRaw is the shared implementation behind Receive and ReceiveInfo.
+func (r *Radio) receiveRaw(timeout time.Duration) ([]byte, int, rxMeta, error) {
+	if err := r.Error(); err != nil {
+		return nil, 0, rxMeta{}, err
+	}
+	listening := r.hw.ReadRegister(RegOpMode)&OpModeListenOn != 0
+	if !listening {
+		r.hw.WriteRegister(RegAutoModes, 0)
+		r.setMode(ReceiverMode)
+		defer r.setMode(SleepMode)
 	}
-	r.hw.WriteRegister(RegAutoModes, 0)
-	r.setMode(ReceiverMode)
-	defer r.setMode(SleepMode)
 	if debug {
 		log.Printf("waiting for interrupt in %s state", r.State())
 	}
@@ -117,6 +200,10 @@ func (r *Radio) Receive(timeout time.Duration) ([]byte, int) {
 	rssi := r.ReadRSSI()
 	length := -1
 	for r.Error() == nil {
+		if r.hw.ReadRegister(RegIrqFlags2)&FifoOverrun != 0 {
+			r.clearFIFO()
+			return nil, rssi, rxMeta{}, ErrFifoOverrun
+		}
 		if r.fifoEmpty() {
 			if timeout <= 0 {
 				break
@@ -125,39 +212,77 @@ func (r *Radio) Receive(timeout time.Duration) ([]byte, int) {
 			timeout -= byteDuration
 			continue
 		}
-		c := r.hw.ReadRegister(RegFifo)
+		if length == -1 {
+			length = int(r.hw.ReadRegister(RegFifo))
+			if r.Error() != nil {
+				break
+			}
+			if length > maxPacketSize+4 {
+				// A bit-flip in the LENGTH byte: don't wait for bytes that
+				// will never arrive, or copy past the real packet boundary.
+				r.clearFIFO()
+				return nil, rssi, rxMeta{}, ErrBadLength
+			}
+			continue
+		}
+		// Drain however much the FIFO is known to hold since the last check,
+		// rather than pulling a single byte per byteDuration: this keeps up
+		// with longer RadioHead payloads without the FIFO overrunning during
+		// reception. FifoLevel only guarantees at least fifoThreshold bytes
+		// are present, not that fifoSize-fifoThreshold are, so that's all we
+		// can safely burst-read at once.
+		remaining := length - r.receiveBuffer.Len()
+		n := 1
+		if r.fifoThresholdExceeded() {
+			n = fifoThreshold
+		}
+		if n > remaining {
+			n = remaining
+		}
+		chunk := r.hw.ReadBurst(RegFifo, n)
 		if r.Error() != nil {
 			break
 		}
-		if length == -1 {
-			length = int(c)
-		} else {
-			r.err = r.receiveBuffer.WriteByte(c)
-			if r.receiveBuffer.Len() == length {
-				// End of packet.
-				return r.finishRX(rssi)
-			}
+		_, r.err = r.receiveBuffer.Write(chunk)
+		if r.receiveBuffer.Len() == length {
+			// End of packet.
+			return r.finishRX(rssi, time.Now())
 		}
 	}
-	return nil, rssi
+	return nil, rssi, rxMeta{}, r.Error()
 }
 
-func (r *Radio) finishRX(rssi int) ([]byte, int) {
+func (r *Radio) finishRX(rssi int, ts time.Time) ([]byte, int, rxMeta, error) {
+	meta := rxMeta{
+		timestamp: ts,
+		crcOK:     r.hw.ReadRegister(RegIrqFlags2)&CrcOk != 0,
+		afc:       afcFeiHz(r.hw.ReadRegister(RegAfcMsb), r.hw.ReadRegister(RegAfcLsb)),
+		fei:       afcFeiHz(r.hw.ReadRegister(RegFeiMsb), r.hw.ReadRegister(RegFeiLsb)),
+	}
+	// Drain any bytes left over in the FIFO: a LENGTH byte corrupted to read
+	// smaller than the real packet makes the loop above stop early, leaving
+	// the rest of the actual packet behind for the next receive to trip over.
+	for !r.fifoEmpty() && r.Error() == nil {
+		r.hw.ReadRegister(RegFifo)
+	}
 	r.setMode(StandbyMode)
+	if !meta.crcOK {
+		r.crcErrors++
+	}
 	size := r.receiveBuffer.Len()
 	if size == 0 {
-		return nil, rssi
+		return nil, rssi, meta, nil
 	}
 	p := make([]byte, size)
 	_, r.err = r.receiveBuffer.Read(p)
-	if r.Error() != nil {
-		return nil, rssi
+	if err := r.Error(); err != nil {
+		return nil, rssi, meta, err
 	}
 	r.receiveBuffer.Reset()
 	if debug {
 		log.Printf("received %d-byte packet in %s state", size, r.State())
 	}
-	return p, rssi
+	return p, rssi, meta, nil
 }
 
 // SendAndReceive transmits the given packet,
@@ -165,10 +290,10 @@ func (r *Radio) finishRX(rssi int) ([]byte, int) {
 // It returns the packet and the associated RSSI.
 // (This could be further optimized by using an Automode to go directly
 // from TX to RX, rather than returning to standby in between.)
-func (r *Radio) SendAndReceive(data []byte, timeout time.Duration) ([]byte, int) {
+func (r *Radio) SendAndReceive(data []byte, timeout time.Duration) ([]byte, int, error) {
 	r.Send(data)
-	if r.Error() != nil {
-		return nil, 0
+	if err := r.Error(); err != nil {
+		return nil, 0, err
 	}
 	return r.Receive(timeout)
 }
@@ -0,0 +1,110 @@
+package rfm69
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RadioHead FLAGS bits used by the reliable datagram layer. These occupy the
+// same header byte as RHReliableDatagram in the RadioHead C++/CircuitPython
+// stacks, so this driver can ACK and be ACKed by those peers.
+const (
+	FlagsAckReq = 0x40 // sender is requesting an ack for this packet
+	FlagsAck    = 0x80 // this packet is itself an ack
+)
+
+// ReliableDatagram adds RHReliableDatagram-style ACKs, retries and duplicate
+// suppression on top of a Radio's plain SendRadioHead/Receive calls, reusing
+// the same 5-byte RadioHead header.
+type ReliableDatagram struct {
+	r          *Radio
+	from       byte
+	retries    int
+	ackTimeout time.Duration
+	nextID     byte
+	lastID     map[byte]byte
+}
+
+// NewReliableDatagram wraps r for node address from. ackTimeout is the
+// initial wait for an ack before retrying; it doubles (plus jitter) on each
+// of up to retries retransmissions.
+func NewReliableDatagram(r *Radio, from byte, retries int, ackTimeout time.Duration) *ReliableDatagram {
+	return &ReliableDatagram{
+		r:          r,
+		from:       from,
+		retries:    retries,
+		ackTimeout: ackTimeout,
+		lastID:     make(map[byte]byte),
+	}
+}
+
+// SendRadioHeadAck sends data to the given node address and waits for a
+// matching ack, retrying with exponential backoff and jitter. It returns an
+// error if no ack arrives within the retry budget.
+func (d *ReliableDatagram) SendRadioHeadAck(data []byte, to byte) error {
+	id := d.nextID
+	d.nextID++
+	timeout := d.ackTimeout
+	for attempt := 0; attempt <= d.retries; attempt++ {
+		d.r.SendRadioHead(data, to, d.from, id, FlagsAckReq)
+		if err := d.r.Error(); err != nil {
+			return err
+		}
+		if d.awaitAck(to, id, timeout) {
+			return nil
+		}
+		jitter := time.Duration(rand.Int63n(int64(d.ackTimeout) + 1))
+		timeout = timeout*2 + jitter
+	}
+	return fmt.Errorf("rfm69: no ack from node %#x after %d attempts", to, d.retries+1)
+}
+
+// awaitAck listens until timeout for an ack from "from" with the given id.
+// Any other traffic received in the meantime is discarded.
+func (d *ReliableDatagram) awaitAck(from byte, id byte, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		p, _, _ := d.r.Receive(remaining)
+		if len(p) < 4 {
+			continue
+		}
+		pTo, pFrom, pID, pFlags := p[0], p[1], p[2], p[3]
+		if pTo == d.from && pFrom == from && pID == id && pFlags&FlagsAck != 0 {
+			return true
+		}
+	}
+}
+
+// ReceiveAck listens for a data packet addressed to this node (or the 0xFF
+// broadcast address), automatically acks it if the sender asked for one, and
+// drops duplicates caused by a retransmission whose original ack was lost.
+// It returns the payload with the RadioHead header stripped.
+func (d *ReliableDatagram) ReceiveAck(timeout time.Duration) ([]byte, int, error) {
+	p, rssi, err := d.r.Receive(timeout)
+	if err != nil || len(p) < 4 {
+		return nil, rssi, err
+	}
+	to, from, id, flags := p[0], p[1], p[2], p[3]
+	if to != d.from && to != 0xFF {
+		return nil, rssi, nil
+	}
+	if flags&FlagsAck != 0 {
+		// An ack meant for SendRadioHeadAck's awaitAck, not data for the
+		// caller; a stray/late one shouldn't be handed up or deduped as if
+		// it were an inbound message.
+		return nil, rssi, nil
+	}
+	if flags&FlagsAckReq != 0 {
+		d.r.SendRadioHead(nil, from, d.from, id, FlagsAck)
+	}
+	if last, seen := d.lastID[from]; seen && last == id {
+		return nil, rssi, nil
+	}
+	d.lastID[from] = id
+	return p[4:], rssi, nil
+}
@@ -0,0 +1,73 @@
+package rfm69
+
+import "time"
+
+// RegAfcMsb/RegAfcLsb/RegFeiMsb/RegFeiLsb are already declared in this
+// package's register map; this file just reads them.
+
+const (
+	// CrcOk is the CRC-ok bit in RegIrqFlags2: set once the radio has
+	// validated the received payload's CRC.
+	CrcOk = 0x02
+
+	// afcFeiStepHz is the frequency resolution of one AFC/FEI LSB, per the
+	// datasheet (FSTEP = FXOSC / 2^19).
+	afcFeiStepHz = 61.03
+)
+
+// rxMeta is the metadata finishRX captures alongside the payload bytes;
+// Receive uses only its CRC flag, ReceiveInfo surfaces it as an RxPacket.
+type rxMeta struct {
+	timestamp time.Time
+	crcOK     bool
+	afc       float64
+	fei       float64
+}
+
+// RxPacket is the rich counterpart to Receive's plain ([]byte, int): besides
+// the payload and RSSI, it carries the instant the payload finished
+// arriving, the radio's AFC/FEI frequency-offset estimates in Hz, whether
+// the CRC passed, and the parsed RadioHead header fields.
+type RxPacket struct {
+	Data      []byte
+	RSSI      int
+	Timestamp time.Time
+	AFC       float64
+	FEI       float64
+	CrcOK     bool
+	To        byte
+	From      byte
+	ID        byte
+	Flags     byte
+}
+
+// ReceiveInfo behaves like Receive, but returns an *RxPacket carrying RSSI,
+// AFC/FEI, CRC status and the parsed RadioHead header, so callers no longer
+// need to slice p[0:4] themselves. It returns a nil packet (and a nil error)
+// if nothing arrived within timeout.
+func (r *Radio) ReceiveInfo(timeout time.Duration) (*RxPacket, error) {
+	p, rssi, meta, err := r.receiveRaw(timeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(p) < 4 {
+		return nil, nil
+	}
+	return &RxPacket{
+		Data:      p[4:],
+		RSSI:      rssi,
+		Timestamp: meta.timestamp,
+		AFC:       meta.afc,
+		FEI:       meta.fei,
+		CrcOK:     meta.crcOK,
+		To:        p[0],
+		From:      p[1],
+		ID:        p[2],
+		Flags:     p[3],
+	}, nil
+}
+
+// afcFeiHz converts a signed 16-bit AFC or FEI register pair to Hz.
+func afcFeiHz(msb, lsb byte) float64 {
+	return float64(int16(uint16(msb)<<8|uint16(lsb))) * afcFeiStepHz
+}